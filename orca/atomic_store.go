@@ -0,0 +1,127 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// AtomicStore is a ServerMetricsStore that records the four scalar
+// ServerMetrics fields using lock-free atomics and the map-valued fields
+// using sync.Map, rather than the single mutex used by InMemoryStore.
+// Servers that record per-call metrics (e.g. SetRequestCost on every RPC,
+// as the interceptors in this package do) avoid contending on a shared lock
+// when using this store in place of the default.
+type AtomicStore struct {
+	cpuUtilization atomic.Uint64 // float64 bits
+	memUtilization atomic.Uint64 // float64 bits
+	qps            atomic.Uint64 // float64 bits
+	eps            atomic.Uint64 // float64 bits
+
+	utilization  sync.Map // string -> float64
+	requestCost  sync.Map // string -> float64
+	namedMetrics sync.Map // string -> float64
+}
+
+// NewAtomicStore returns a new AtomicStore with all scalar fields unset.
+func NewAtomicStore() *AtomicStore {
+	s := &AtomicStore{}
+	unset := math.Float64bits(-1)
+	s.cpuUtilization.Store(unset)
+	s.memUtilization.Store(unset)
+	s.qps.Store(unset)
+	s.eps.Store(unset)
+	return s
+}
+
+func (s *AtomicStore) scalar(field ServerMetricsField) *atomic.Uint64 {
+	switch field {
+	case CPUUtilizationField:
+		return &s.cpuUtilization
+	case MemoryUtilizationField:
+		return &s.memUtilization
+	case QPSField:
+		return &s.qps
+	case EPSField:
+		return &s.eps
+	}
+	return nil
+}
+
+// Set implements ServerMetricsStore.
+func (s *AtomicStore) Set(field ServerMetricsField, val float64) {
+	if p := s.scalar(field); p != nil {
+		p.Store(math.Float64bits(val))
+	}
+}
+
+// Delete implements ServerMetricsStore.
+func (s *AtomicStore) Delete(field ServerMetricsField) {
+	s.Set(field, -1)
+}
+
+func (s *AtomicStore) mapFor(field ServerMetricsMapField) *sync.Map {
+	switch field {
+	case UtilizationField:
+		return &s.utilization
+	case RequestCostField:
+		return &s.requestCost
+	case NamedMetricsField:
+		return &s.namedMetrics
+	}
+	return nil
+}
+
+// SetMap implements ServerMetricsStore.
+func (s *AtomicStore) SetMap(field ServerMetricsMapField, name string, val float64) {
+	if m := s.mapFor(field); m != nil {
+		m.Store(name, val)
+	}
+}
+
+// DeleteMap implements ServerMetricsStore.
+func (s *AtomicStore) DeleteMap(field ServerMetricsMapField, name string) {
+	if m := s.mapFor(field); m != nil {
+		m.Delete(name)
+	}
+}
+
+// Snapshot implements ServerMetricsStore.
+func (s *AtomicStore) Snapshot() *ServerMetrics {
+	return &ServerMetrics{
+		CPUUtilization: math.Float64frombits(s.cpuUtilization.Load()),
+		MemUtilization: math.Float64frombits(s.memUtilization.Load()),
+		QPS:            math.Float64frombits(s.qps.Load()),
+		EPS:            math.Float64frombits(s.eps.Load()),
+		Utilization:    snapshotSyncMap(&s.utilization),
+		RequestCost:    snapshotSyncMap(&s.requestCost),
+		NamedMetrics:   snapshotSyncMap(&s.namedMetrics),
+	}
+}
+
+func snapshotSyncMap(m *sync.Map) map[string]float64 {
+	ret := make(map[string]float64)
+	m.Range(func(key, val any) bool {
+		ret[key.(string)] = val.(float64)
+		return true
+	})
+	return ret
+}
@@ -0,0 +1,84 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import "testing"
+
+func TestServerMetricsStores(t *testing.T) {
+	for name, newStore := range map[string]func() ServerMetricsStore{
+		"InMemoryStore": func() ServerMetricsStore { return NewInMemoryStore() },
+		"AtomicStore":   func() ServerMetricsStore { return NewAtomicStore() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newStore()
+
+			sm := store.Snapshot()
+			if sm.CPUUtilization != -1 || sm.MemUtilization != -1 || sm.QPS != -1 || sm.EPS != -1 {
+				t.Fatalf("new store should start with all scalar fields unset, got %+v", sm)
+			}
+			if len(sm.Utilization) != 0 || len(sm.RequestCost) != 0 || len(sm.NamedMetrics) != 0 {
+				t.Fatalf("new store should start with all map fields empty, got %+v", sm)
+			}
+
+			store.Set(CPUUtilizationField, 0.5)
+			store.Set(QPSField, 42)
+			store.SetMap(UtilizationField, "foo", 0.25)
+			store.SetMap(NamedMetricsField, "bar", 7)
+
+			sm = store.Snapshot()
+			if sm.CPUUtilization != 0.5 {
+				t.Errorf("CPUUtilization = %v, want 0.5", sm.CPUUtilization)
+			}
+			if sm.QPS != 42 {
+				t.Errorf("QPS = %v, want 42", sm.QPS)
+			}
+			if sm.Utilization["foo"] != 0.25 {
+				t.Errorf("Utilization[foo] = %v, want 0.25", sm.Utilization["foo"])
+			}
+			if sm.NamedMetrics["bar"] != 7 {
+				t.Errorf("NamedMetrics[bar] = %v, want 7", sm.NamedMetrics["bar"])
+			}
+
+			store.Delete(CPUUtilizationField)
+			store.DeleteMap(UtilizationField, "foo")
+
+			sm = store.Snapshot()
+			if sm.CPUUtilization != -1 {
+				t.Errorf("CPUUtilization after Delete = %v, want -1", sm.CPUUtilization)
+			}
+			if _, ok := sm.Utilization["foo"]; ok {
+				t.Errorf("Utilization[foo] still present after DeleteMap")
+			}
+
+			// Snapshot must return a copy, not a view into the store.
+			sm.NamedMetrics["bar"] = 999
+			if got := store.Snapshot().NamedMetrics["bar"]; got != 7 {
+				t.Errorf("mutating a returned Snapshot affected the store; NamedMetrics[bar] = %v, want 7", got)
+			}
+		})
+	}
+}
+
+func TestNewServerMetricsRecorderWithStore(t *testing.T) {
+	recorder := NewServerMetricsRecorderWithStore(NewAtomicStore())
+	recorder.SetCPUUtilization(0.9)
+	if got := recorder.ServerMetrics().CPUUtilization; got != 0.9 {
+		t.Errorf("CPUUtilization = %v, want 0.9", got)
+	}
+}
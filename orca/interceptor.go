@@ -0,0 +1,240 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// CallMetricsRecorder allows a server method handler to report per-call
+// cost metrics, in addition to the server-wide metrics recorded through a
+// ServerMetricsRecorder.  A CallMetricsRecorder is attached to the context
+// of RPCs handled by the interceptors in this file; retrieve it with
+// CallMetricsRecorderFromContext.
+type CallMetricsRecorder interface {
+	// SetRequestCost records a measurement for a call cost metric uniquely
+	// identifiable by name.
+	SetRequestCost(name string, val float64)
+	// SetNamedMetric records a measurement for a custom call metric
+	// uniquely identifiable by name.
+	SetNamedMetric(name string, val float64)
+}
+
+type callMetricsRecorderKey struct{}
+
+// CallMetricsRecorderFromContext returns the CallMetricsRecorder attached to
+// ctx by UnaryServerInterceptor or StreamServerInterceptor, if any.
+func CallMetricsRecorderFromContext(ctx context.Context) (CallMetricsRecorder, bool) {
+	r, ok := ctx.Value(callMetricsRecorderKey{}).(CallMetricsRecorder)
+	return r, ok
+}
+
+// InterceptorOption configures the interceptors returned by
+// UnaryServerInterceptor and StreamServerInterceptor.
+type InterceptorOption func(*interceptorOptions)
+
+type interceptorOptions struct {
+	decayWindow time.Duration
+}
+
+// defaultDecayWindow is the window over which QPS and EPS are computed when
+// WithDecayWindow is not supplied.
+const defaultDecayWindow = time.Second
+
+// WithDecayWindow sets the window over which QPS and EPS are computed.  RPCs
+// are counted for the duration of the window, after which the rate is
+// reported to the ServerMetricsRecorder and the counters reset.  The default
+// is one second.
+func WithDecayWindow(d time.Duration) InterceptorOption {
+	return func(o *interceptorOptions) { o.decayWindow = d }
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that reports
+// QPS and EPS to recorder and, if recorder also implements
+// CallMetricsRecorder (as the value returned by NewServerMetricsRecorder
+// does), attaches it to the context of each call so that handlers can
+// report per-call cost via CallMetricsRecorderFromContext without holding a
+// direct reference to recorder.  It also returns a stop func that must be
+// called (e.g. via defer in the code that installs the interceptor) to
+// release the interceptor's background goroutine once the server is torn
+// down.
+//
+// Interceptors for the same recorder, from either UnaryServerInterceptor or
+// StreamServerInterceptor, share a single underlying rate tracker so that
+// QPS/EPS reflect combined unary and streaming traffic rather than
+// whichever interceptor's ticker last fired.
+func UnaryServerInterceptor(recorder ServerMetricsRecorder, opts ...InterceptorOption) (grpc.UnaryServerInterceptor, func()) {
+	o := interceptorOptions{decayWindow: defaultDecayWindow}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tracker, stop := acquireRPCRateTracker(recorder, o.decayWindow)
+	cmr, _ := recorder.(CallMetricsRecorder)
+
+	interceptor := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		if cmr != nil {
+			ctx = context.WithValue(ctx, callMetricsRecorderKey{}, cmr)
+		}
+		resp, err := handler(ctx, req)
+		tracker.recordRPC(err)
+		if cmr != nil {
+			cmr.SetRequestCost("method:"+info.FullMethod, time.Since(start).Seconds())
+		}
+		return resp, err
+	}
+	return interceptor, stop
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor with the
+// same QPS/EPS reporting, context attachment, shared-tracker, and teardown
+// behavior as UnaryServerInterceptor.
+func StreamServerInterceptor(recorder ServerMetricsRecorder, opts ...InterceptorOption) (grpc.StreamServerInterceptor, func()) {
+	o := interceptorOptions{decayWindow: defaultDecayWindow}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tracker, stop := acquireRPCRateTracker(recorder, o.decayWindow)
+	cmr, _ := recorder.(CallMetricsRecorder)
+
+	interceptor := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		wrapped := ss
+		if cmr != nil {
+			wrapped = &recorderServerStream{
+				ServerStream: ss,
+				ctx:          context.WithValue(ss.Context(), callMetricsRecorderKey{}, cmr),
+			}
+		}
+		err := handler(srv, wrapped)
+		tracker.recordRPC(err)
+		if cmr != nil {
+			cmr.SetRequestCost("method:"+info.FullMethod, time.Since(start).Seconds())
+		}
+		return err
+	}
+	return interceptor, stop
+}
+
+// recorderServerStream overrides Context to return a context carrying a
+// CallMetricsRecorder.
+type recorderServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *recorderServerStream) Context() context.Context { return s.ctx }
+
+// rpcRateTracker counts RPCs and failed RPCs and periodically reports QPS
+// and EPS to a ServerMetricsRecorder over a decaying window.
+type rpcRateTracker struct {
+	recorder ServerMetricsRecorder
+	window   time.Duration
+
+	requests atomic.Uint64
+	errors   atomic.Uint64
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newRPCRateTracker(recorder ServerMetricsRecorder, window time.Duration) *rpcRateTracker {
+	t := &rpcRateTracker{recorder: recorder, window: window, done: make(chan struct{})}
+	go t.run()
+	return t
+}
+
+func (t *rpcRateTracker) run() {
+	ticker := time.NewTicker(t.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			requests := t.requests.Swap(0)
+			errors := t.errors.Swap(0)
+			secs := t.window.Seconds()
+			t.recorder.SetQPS(float64(requests) / secs)
+			t.recorder.SetEPS(float64(errors) / secs)
+		}
+	}
+}
+
+func (t *rpcRateTracker) recordRPC(err error) {
+	t.requests.Add(1)
+	if err != nil {
+		t.errors.Add(1)
+	}
+}
+
+func (t *rpcRateTracker) stop() {
+	t.stopOnce.Do(func() { close(t.done) })
+}
+
+// sharedTracker is a reference-counted rpcRateTracker shared by every
+// interceptor constructed for the same recorder.
+type sharedTracker struct {
+	tracker  *rpcRateTracker
+	refCount int
+}
+
+var (
+	sharedTrackersMu sync.Mutex
+	sharedTrackers   = make(map[ServerMetricsRecorder]*sharedTracker)
+)
+
+// acquireRPCRateTracker returns the rpcRateTracker shared by all
+// interceptors constructed for recorder, creating one with the given window
+// if this is the first acquisition, and increments its reference count. The
+// returned release func decrements the count and stops the tracker once the
+// last interceptor sharing it is released; it is safe to call more than
+// once.
+func acquireRPCRateTracker(recorder ServerMetricsRecorder, window time.Duration) (*rpcRateTracker, func()) {
+	sharedTrackersMu.Lock()
+	st, ok := sharedTrackers[recorder]
+	if !ok {
+		st = &sharedTracker{tracker: newRPCRateTracker(recorder, window)}
+		sharedTrackers[recorder] = st
+	}
+	st.refCount++
+	sharedTrackersMu.Unlock()
+
+	var releaseOnce sync.Once
+	release := func() {
+		releaseOnce.Do(func() {
+			sharedTrackersMu.Lock()
+			st.refCount--
+			last := st.refCount == 0
+			if last {
+				delete(sharedTrackers, recorder)
+			}
+			sharedTrackersMu.Unlock()
+			if last {
+				st.tracker.stop()
+			}
+		})
+	}
+	return st.tracker, release
+}
@@ -0,0 +1,98 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUnaryAndStreamInterceptorsShareTracker(t *testing.T) {
+	recorder := NewServerMetricsRecorder()
+
+	_, stopUnary := UnaryServerInterceptor(recorder, WithDecayWindow(time.Hour))
+	_, stopStream := StreamServerInterceptor(recorder, WithDecayWindow(time.Hour))
+	defer stopUnary()
+	defer stopStream()
+
+	sharedTrackersMu.Lock()
+	st := sharedTrackers[recorder]
+	sharedTrackersMu.Unlock()
+	if st == nil {
+		t.Fatal("expected a shared tracker to be registered for recorder")
+	}
+	if st.refCount != 2 {
+		t.Errorf("refCount = %d, want 2", st.refCount)
+	}
+}
+
+func TestAcquireRPCRateTrackerReusesTrackerUntilLastRelease(t *testing.T) {
+	recorder := NewServerMetricsRecorder()
+
+	tracker1, release1 := acquireRPCRateTracker(recorder, time.Hour)
+	tracker2, release2 := acquireRPCRateTracker(recorder, time.Hour)
+	if tracker1 != tracker2 {
+		t.Fatal("expected the same tracker instance to be reused for the same recorder")
+	}
+
+	release1()
+	sharedTrackersMu.Lock()
+	_, stillTracked := sharedTrackers[recorder]
+	sharedTrackersMu.Unlock()
+	if !stillTracked {
+		t.Fatal("tracker released while a reference was still outstanding")
+	}
+
+	release2()
+	sharedTrackersMu.Lock()
+	_, stillTracked = sharedTrackers[recorder]
+	sharedTrackersMu.Unlock()
+	if stillTracked {
+		t.Fatal("tracker not released after its last reference was released")
+	}
+
+	// Releasing again must not panic or double-stop.
+	release2()
+}
+
+func TestRPCRateTrackerReportsQPSAndEPS(t *testing.T) {
+	recorder := NewServerMetricsRecorder()
+	tracker := newRPCRateTracker(recorder, 20*time.Millisecond)
+	defer tracker.stop()
+
+	tracker.recordRPC(nil)
+	tracker.recordRPC(nil)
+	tracker.recordRPC(context.DeadlineExceeded)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if sm := recorder.ServerMetrics(); sm.QPS != -1 {
+			if sm.QPS <= 0 {
+				t.Errorf("QPS = %v, want > 0", sm.QPS)
+			}
+			if sm.EPS <= 0 {
+				t.Errorf("EPS = %v, want > 0", sm.EPS)
+			}
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for QPS/EPS to be reported")
+}
@@ -0,0 +1,79 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// NewMetricsHandler returns an http.Handler that, on GET, serializes the
+// ServerMetrics currently held by provider in Prometheus text exposition
+// format.  This is for deployments that already run a metrics HTTP server
+// alongside their gRPC server (e.g. on a separate admin port) and want to
+// expose ORCA state over that channel without standing up a full
+// prometheus.Registerer; see the orcaprom package for that alternative.
+func NewMetricsHandler(provider ServerMetricsProvider) http.Handler {
+	return &metricsHandler{provider: provider}
+}
+
+type metricsHandler struct {
+	provider ServerMetricsProvider
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	sm := h.provider.ServerMetrics()
+	writeGauge(w, "grpc_server_cpu_utilization", "Current CPU utilization, as reported via ORCA.", sm.CPUUtilization)
+	writeGauge(w, "grpc_server_memory_utilization", "Current memory utilization, as reported via ORCA.", sm.MemUtilization)
+	writeGauge(w, "grpc_server_qps", "Current queries per second, as reported via ORCA.", sm.QPS)
+	writeGauge(w, "grpc_server_eps", "Current errors per second, as reported via ORCA.", sm.EPS)
+	writeGaugeFamily(w, "grpc_server_utilization", "Custom utilization metric, as reported via ORCA.", sm.Utilization)
+	writeGaugeFamily(w, "grpc_server_named_metric", "Custom named metric, as reported via ORCA.", sm.NamedMetrics)
+}
+
+// writeGauge writes a single gauge sample in text exposition format, unless
+// val is the "unset" sentinel (-1), in which case it is omitted entirely.
+func writeGauge(w io.Writer, name, help string, val float64) {
+	if val == -1 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, val)
+}
+
+// writeGaugeFamily writes one gauge sample per entry in vals, labeled with
+// the map key as "name", skipping any -1 sentinels.
+func writeGaugeFamily(w io.Writer, name, help string, vals map[string]float64) {
+	if len(vals) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for k, v := range vals {
+		if v == -1 {
+			continue
+		}
+		fmt.Fprintf(w, "%s{name=%q} %v\n", name, k, v)
+	}
+}
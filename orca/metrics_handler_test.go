@@ -0,0 +1,66 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsHandlerServeHTTP(t *testing.T) {
+	recorder := NewServerMetricsRecorder()
+	recorder.SetCPUUtilization(0.5)
+	recorder.SetQPS(10)
+	recorder.SetNamedUtilization("foo", 0.25)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	NewMetricsHandler(recorder).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"grpc_server_cpu_utilization 0.5",
+		"grpc_server_qps 10",
+		`grpc_server_utilization{name="foo"} 0.25`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("response body missing %q; got:\n%s", want, body)
+		}
+	}
+	for _, notWant := range []string{"grpc_server_memory_utilization", "grpc_server_eps"} {
+		if strings.Contains(body, notWant) {
+			t.Errorf("response body should omit unset metric %q; got:\n%s", notWant, body)
+		}
+	}
+}
+
+func TestMetricsHandlerRejectsNonGET(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	NewMetricsHandler(NewServerMetricsRecorder()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package orcaprom adapts an orca.ServerMetricsRecorder into a
+// prometheus.Collector, so that out-of-band server metrics recorded for
+// ORCA can also be scraped through an existing Prometheus registry.
+package orcaprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/orca"
+)
+
+const namespace = "grpc_server"
+
+var (
+	cpuUtilizationDesc = prometheus.NewDesc(namespace+"_cpu_utilization", "Current CPU utilization, as reported via ORCA.", nil, nil)
+	memUtilizationDesc = prometheus.NewDesc(namespace+"_memory_utilization", "Current memory utilization, as reported via ORCA.", nil, nil)
+	qpsDesc            = prometheus.NewDesc(namespace+"_qps", "Current queries per second, as reported via ORCA.", nil, nil)
+	epsDesc            = prometheus.NewDesc(namespace+"_eps", "Current errors per second, as reported via ORCA.", nil, nil)
+	utilizationDesc    = prometheus.NewDesc(namespace+"_utilization", "Custom utilization metric, as reported via ORCA.", []string{"name"}, nil)
+	namedMetricDesc    = prometheus.NewDesc(namespace+"_named_metric", "Custom named metric, as reported via ORCA.", []string{"name"}, nil)
+)
+
+// Collector is a prometheus.Collector that reports the metrics currently
+// held by a ServerMetricsRecorder.  Use New to construct one, and register
+// it with a prometheus.Registerer the same as any other collector.
+type Collector struct {
+	recorder orca.ServerMetricsRecorder
+}
+
+// New returns a Collector that reports the metrics recorded in recorder.
+func New(recorder orca.ServerMetricsRecorder) *Collector {
+	return &Collector{recorder: recorder}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cpuUtilizationDesc
+	ch <- memUtilizationDesc
+	ch <- qpsDesc
+	ch <- epsDesc
+	ch <- utilizationDesc
+	ch <- namedMetricDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	sm := c.recorder.ServerMetrics()
+
+	collect(ch, cpuUtilizationDesc, sm.CPUUtilization)
+	collect(ch, memUtilizationDesc, sm.MemUtilization)
+	collect(ch, qpsDesc, sm.QPS)
+	collect(ch, epsDesc, sm.EPS)
+	for name, val := range sm.Utilization {
+		collect(ch, utilizationDesc, val, name)
+	}
+	for name, val := range sm.NamedMetrics {
+		collect(ch, namedMetricDesc, val, name)
+	}
+}
+
+// collect emits val on desc as a gauge sample with the given label values,
+// unless val is the "unset" sentinel (-1), in which case it is skipped.
+func collect(ch chan<- prometheus.Metric, desc *prometheus.Desc, val float64, labelValues ...string) {
+	if val == -1 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, val, labelValues...)
+}
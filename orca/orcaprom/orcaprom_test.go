@@ -0,0 +1,139 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orcaprom_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc/orca"
+	"google.golang.org/grpc/orca/orcaprom"
+)
+
+// sample is a flattened view of one collected prometheus.Metric, since
+// multiple samples for a name-labeled Desc (e.g. utilizationDesc) cannot be
+// distinguished by Desc alone.
+type sample struct {
+	desc   string
+	labels map[string]string
+	value  float64
+}
+
+func collect(t *testing.T, c *orcaprom.Collector) []sample {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 16)
+	c.Collect(ch)
+	close(ch)
+
+	var got []sample
+	for m := range ch {
+		var d dto.Metric
+		if err := m.Write(&d); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+		labels := make(map[string]string, len(d.GetLabel()))
+		for _, lp := range d.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		got = append(got, sample{desc: m.Desc().String(), labels: labels, value: d.GetGauge().GetValue()})
+	}
+	return got
+}
+
+func TestCollectReportsSetMetrics(t *testing.T) {
+	recorder := orca.NewServerMetricsRecorder()
+	recorder.SetCPUUtilization(0.5)
+	recorder.SetMemoryUtilization(0.25)
+	recorder.SetQPS(100)
+	recorder.SetNamedUtilization("custom", 0.75)
+
+	got := collect(t, orcaprom.New(recorder))
+
+	// CPU, memory, QPS, and the one named utilization are set; EPS is not.
+	if len(got) != 4 {
+		t.Fatalf("got %d metrics, want 4: %+v", len(got), got)
+	}
+
+	var foundCustom bool
+	for _, s := range got {
+		if s.labels["name"] == "custom" {
+			foundCustom = true
+			if s.value != 0.75 {
+				t.Errorf("custom utilization value = %v, want 0.75", s.value)
+			}
+		}
+	}
+	if !foundCustom {
+		t.Errorf("no sample labeled name=%q found in %+v", "custom", got)
+	}
+}
+
+func TestCollectSkipsUnsetMetrics(t *testing.T) {
+	recorder := orca.NewServerMetricsRecorder()
+
+	got := collect(t, orcaprom.New(recorder))
+
+	if len(got) != 0 {
+		t.Fatalf("got %d metrics for a recorder with nothing set, want 0: %+v", len(got), got)
+	}
+}
+
+func TestCollectReportsMultipleNamedUtilizationEntries(t *testing.T) {
+	recorder := orca.NewServerMetricsRecorder()
+	recorder.SetNamedUtilization("foo", 0.1)
+	recorder.SetNamedUtilization("bar", 0.2)
+
+	got := collect(t, orcaprom.New(recorder))
+
+	want := map[string]float64{"foo": 0.1, "bar": 0.2}
+	if len(got) != len(want) {
+		t.Fatalf("got %d metrics, want %d: %+v", len(got), len(want), got)
+	}
+	for _, s := range got {
+		v, ok := want[s.labels["name"]]
+		if !ok {
+			t.Errorf("unexpected sample: %+v", s)
+			continue
+		}
+		if s.value != v {
+			t.Errorf("sample %q = %v, want %v", s.labels["name"], s.value, v)
+		}
+	}
+}
+
+func TestDescribeEmitsFixedDescriptors(t *testing.T) {
+	recorder := orca.NewServerMetricsRecorder()
+	recorder.SetNamedUtilization("custom", 0.5)
+
+	ch := make(chan *prometheus.Desc, 16)
+	orcaprom.New(recorder).Describe(ch)
+	close(ch)
+
+	// The 4 scalar descriptors plus the name-labeled utilization and
+	// named-metric descriptors: exactly 6, regardless of how many distinct
+	// names are currently present in the recorder's maps.
+	var count int
+	for range ch {
+		count++
+	}
+	if count != 6 {
+		t.Errorf("got %d descriptors, want 6", count)
+	}
+}
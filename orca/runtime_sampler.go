@@ -0,0 +1,173 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+const (
+	cpuUserMetric     = "/cpu/classes/user:cpu-seconds"
+	cpuGCMetric       = "/cpu/classes/gc/total:cpu-seconds"
+	heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+)
+
+// RuntimeSamplerOption configures a RuntimeSampler constructed by
+// NewRuntimeSampler.
+type RuntimeSamplerOption func(*RuntimeSampler)
+
+// WithMemoryBudget sets the number of bytes that heap usage is divided by to
+// produce a memory utilization value in [0, 1.0].  If not set, memory
+// utilization is left unreported.  This is typically the cgroup or rlimit
+// memory limit applied to the process.
+func WithMemoryBudget(bytes uint64) RuntimeSamplerOption {
+	return func(rs *RuntimeSampler) { rs.memBudget = bytes }
+}
+
+// RuntimeSampler periodically reads process-wide CPU and memory usage via
+// the runtime/metrics package and reports them to a ServerMetricsRecorder,
+// removing the need for every server to wire up its own ticker for this
+// common case.  Construct one with NewRuntimeSampler, and call Stop when
+// finished with it.
+type RuntimeSampler struct {
+	recorder  ServerMetricsRecorder
+	interval  time.Duration
+	memBudget uint64
+
+	lastSampleTime time.Time
+	lastCPUSeconds float64
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewRuntimeSampler creates a RuntimeSampler that samples process metrics
+// every interval and reports them to recorder via SetCPUUtilization and, if
+// WithMemoryBudget is supplied, SetMemoryUtilization.  Sampling runs in a
+// background goroutine started immediately; the first sample is reported
+// one interval after construction.
+func NewRuntimeSampler(recorder ServerMetricsRecorder, interval time.Duration, opts ...RuntimeSamplerOption) *RuntimeSampler {
+	rs := &RuntimeSampler{
+		recorder:       recorder,
+		interval:       interval,
+		lastSampleTime: time.Now(),
+		done:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(rs)
+	}
+	rs.lastCPUSeconds, _ = readCPUSeconds()
+	go rs.run()
+	return rs
+}
+
+// Stop terminates the background sampling goroutine.  It is safe to call
+// Stop more than once, and safe to call from any goroutine.
+func (rs *RuntimeSampler) Stop() {
+	rs.stopOnce.Do(func() { close(rs.done) })
+}
+
+func (rs *RuntimeSampler) run() {
+	ticker := time.NewTicker(rs.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rs.done:
+			return
+		case now := <-ticker.C:
+			rs.sample(now)
+		}
+	}
+}
+
+func (rs *RuntimeSampler) sample(now time.Time) {
+	elapsed := now.Sub(rs.lastSampleTime).Seconds()
+	rs.lastSampleTime = now
+
+	if cpuSeconds, ok := readCPUSeconds(); ok && elapsed > 0 {
+		delta := cpuSeconds - rs.lastCPUSeconds
+		rs.lastCPUSeconds = cpuSeconds
+		rs.recorder.SetCPUUtilization(clamp01(delta / (elapsed * float64(runtime.GOMAXPROCS(0)))))
+	}
+	// On Go versions without the /cpu/classes/* metrics there is no
+	// equivalent aggregate CPU time available from the runtime package, so
+	// CPU utilization is simply left unreported on that sample.
+
+	if heapBytes, ok := readUint64Metric(heapObjectsMetric); ok {
+		rs.reportMemory(float64(heapBytes))
+	} else {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		rs.reportMemory(float64(ms.HeapAlloc))
+	}
+}
+
+func (rs *RuntimeSampler) reportMemory(heapBytes float64) {
+	if rs.memBudget == 0 {
+		return
+	}
+	rs.recorder.SetMemoryUtilization(clamp01(heapBytes / float64(rs.memBudget)))
+}
+
+// readCPUSeconds returns the cumulative user+GC CPU time consumed by the
+// process in seconds, as reported by runtime/metrics.  The second return
+// value is false if the relevant metrics are unavailable (e.g. on Go
+// versions that predate their introduction).
+func readCPUSeconds() (float64, bool) {
+	user, ok := readFloat64Metric(cpuUserMetric)
+	if !ok {
+		return 0, false
+	}
+	gc, ok := readFloat64Metric(cpuGCMetric)
+	if !ok {
+		return 0, false
+	}
+	return user + gc, true
+}
+
+func readFloat64Metric(name string) (float64, bool) {
+	samples := []metrics.Sample{{Name: name}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() == metrics.KindBad {
+		return 0, false
+	}
+	return samples[0].Value.Float64(), true
+}
+
+func readUint64Metric(name string) (uint64, bool) {
+	samples := []metrics.Sample{{Name: name}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() == metrics.KindBad {
+		return 0, false
+	}
+	return samples[0].Value.Uint64(), true
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
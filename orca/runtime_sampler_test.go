@@ -0,0 +1,76 @@
+/*
+ *
+ * Copyright 2024 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package orca
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClamp01(t *testing.T) {
+	for _, tt := range []struct {
+		in, want float64
+	}{
+		{-1, 0},
+		{0, 0},
+		{0.5, 0.5},
+		{1, 1},
+		{2, 1},
+	} {
+		if got := clamp01(tt.in); got != tt.want {
+			t.Errorf("clamp01(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRuntimeSamplerReportsMemoryUtilization(t *testing.T) {
+	recorder := NewServerMetricsRecorder()
+	rs := NewRuntimeSampler(recorder, 10*time.Millisecond, WithMemoryBudget(1<<30))
+	defer rs.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if sm := recorder.ServerMetrics(); sm.MemUtilization != -1 {
+			if sm.MemUtilization < 0 || sm.MemUtilization > 1 {
+				t.Fatalf("MemUtilization = %v, want in [0, 1]", sm.MemUtilization)
+			}
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for memory utilization to be reported")
+}
+
+func TestRuntimeSamplerWithoutMemoryBudgetLeavesMemoryUnset(t *testing.T) {
+	recorder := NewServerMetricsRecorder()
+	rs := NewRuntimeSampler(recorder, 10*time.Millisecond)
+	defer rs.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if sm := recorder.ServerMetrics(); sm.MemUtilization != -1 {
+		t.Errorf("MemUtilization = %v, want -1 (unset) when no memory budget is configured", sm.MemUtilization)
+	}
+}
+
+func TestRuntimeSamplerStopIsIdempotent(t *testing.T) {
+	recorder := NewServerMetricsRecorder()
+	rs := NewRuntimeSampler(recorder, 5*time.Millisecond)
+	rs.Stop()
+	rs.Stop()
+}
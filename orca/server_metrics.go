@@ -122,20 +122,63 @@ type ServerMetricsRecorder interface {
 	DeleteNamedUtilization(name string)
 }
 
-type serverMetricsRecorder struct {
-	mu    sync.Mutex     // protects state
-	state *ServerMetrics // the current metrics
+// ServerMetricsField identifies one of the scalar, float64-valued fields of
+// ServerMetrics.
+type ServerMetricsField int
+
+const (
+	// CPUUtilizationField identifies ServerMetrics.CPUUtilization.
+	CPUUtilizationField ServerMetricsField = iota
+	// MemoryUtilizationField identifies ServerMetrics.MemUtilization.
+	MemoryUtilizationField
+	// QPSField identifies ServerMetrics.QPS.
+	QPSField
+	// EPSField identifies ServerMetrics.EPS.
+	EPSField
+)
+
+// ServerMetricsMapField identifies one of the map-valued fields of
+// ServerMetrics.
+type ServerMetricsMapField int
+
+const (
+	// UtilizationField identifies ServerMetrics.Utilization.
+	UtilizationField ServerMetricsMapField = iota
+	// RequestCostField identifies ServerMetrics.RequestCost.
+	RequestCostField
+	// NamedMetricsField identifies ServerMetrics.NamedMetrics.
+	NamedMetricsField
+)
+
+// ServerMetricsStore abstracts the storage of ServerMetrics state away from
+// serverMetricsRecorder, allowing alternate implementations (e.g.
+// AtomicStore) to be used in place of the default InMemoryStore.
+type ServerMetricsStore interface {
+	// Snapshot returns a point-in-time copy of the stored ServerMetrics.
+	Snapshot() *ServerMetrics
+	// Set records val for the given scalar field.
+	Set(field ServerMetricsField, val float64)
+	// Delete clears any previously recorded value for the given scalar
+	// field.
+	Delete(field ServerMetricsField)
+	// SetMap records val for name within the given map-valued field.
+	SetMap(field ServerMetricsMapField, name string, val float64)
+	// DeleteMap clears any previously recorded value for name within the
+	// given map-valued field.
+	DeleteMap(field ServerMetricsMapField, name string)
 }
 
-// NewServerMetricsRecorder returns an in-memory store for ServerMetrics and
-// allows for safe setting and retrieving of ServerMetrics.  Also implements
-// ServerMetricsProvider for use with NewService.
-func NewServerMetricsRecorder() ServerMetricsRecorder {
-	return newServerMetricsRecorder()
+// InMemoryStore is a ServerMetricsStore that holds ServerMetrics state in
+// memory behind a single mutex.  It is the store used by
+// NewServerMetricsRecorder.
+type InMemoryStore struct {
+	mu    sync.Mutex     // protects state
+	state *ServerMetrics // the current metrics
 }
 
-func newServerMetricsRecorder() *serverMetricsRecorder {
-	return &serverMetricsRecorder{
+// NewInMemoryStore returns a new InMemoryStore with all fields unset.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
 		state: &ServerMetrics{
 			CPUUtilization: -1,
 			MemUtilization: -1,
@@ -148,8 +191,8 @@ func newServerMetricsRecorder() *serverMetricsRecorder {
 	}
 }
 
-// ServerMetrics returns a copy of the current ServerMetrics.
-func (s *serverMetricsRecorder) ServerMetrics() *ServerMetrics {
+// Snapshot implements ServerMetricsStore.
+func (s *InMemoryStore) Snapshot() *ServerMetrics {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return &ServerMetrics{
@@ -171,100 +214,155 @@ func copyMap(m map[string]float64) map[string]float64 {
 	return ret
 }
 
-// SetCPUUtilization records a measurement for the CPU utilization metric.
-func (s *serverMetricsRecorder) SetCPUUtilization(val float64) {
+// Set implements ServerMetricsStore.
+func (s *InMemoryStore) Set(field ServerMetricsField, val float64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.state.CPUUtilization = val
+	switch field {
+	case CPUUtilizationField:
+		s.state.CPUUtilization = val
+	case MemoryUtilizationField:
+		s.state.MemUtilization = val
+	case QPSField:
+		s.state.QPS = val
+	case EPSField:
+		s.state.EPS = val
+	}
+}
+
+// Delete implements ServerMetricsStore.
+func (s *InMemoryStore) Delete(field ServerMetricsField) {
+	s.Set(field, -1)
+}
+
+func (s *InMemoryStore) mapFor(field ServerMetricsMapField) map[string]float64 {
+	switch field {
+	case UtilizationField:
+		return s.state.Utilization
+	case RequestCostField:
+		return s.state.RequestCost
+	case NamedMetricsField:
+		return s.state.NamedMetrics
+	}
+	return nil
+}
+
+// SetMap implements ServerMetricsStore.
+func (s *InMemoryStore) SetMap(field ServerMetricsMapField, name string, val float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m := s.mapFor(field); m != nil {
+		m[name] = val
+	}
+}
+
+// DeleteMap implements ServerMetricsStore.
+func (s *InMemoryStore) DeleteMap(field ServerMetricsMapField, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m := s.mapFor(field); m != nil {
+		delete(m, name)
+	}
+}
+
+type serverMetricsRecorder struct {
+	store ServerMetricsStore
+}
+
+// NewServerMetricsRecorder returns an in-memory store for ServerMetrics and
+// allows for safe setting and retrieving of ServerMetrics.  Also implements
+// ServerMetricsProvider for use with NewService.
+func NewServerMetricsRecorder() ServerMetricsRecorder {
+	return NewServerMetricsRecorderWithStore(NewInMemoryStore())
+}
+
+// NewServerMetricsRecorderWithStore returns a ServerMetricsRecorder backed
+// by store, allowing callers to supply an alternate ServerMetricsStore
+// implementation (e.g. AtomicStore) in place of the InMemoryStore used by
+// NewServerMetricsRecorder.
+func NewServerMetricsRecorderWithStore(store ServerMetricsStore) ServerMetricsRecorder {
+	return &serverMetricsRecorder{store: store}
+}
+
+// ServerMetrics returns a copy of the current ServerMetrics.
+func (s *serverMetricsRecorder) ServerMetrics() *ServerMetrics {
+	return s.store.Snapshot()
+}
+
+// SetCPUUtilization records a measurement for the CPU utilization metric.
+func (s *serverMetricsRecorder) SetCPUUtilization(val float64) {
+	s.store.Set(CPUUtilizationField, val)
 }
 
 // DeleteCPUUtilization deletes the relevant server metric to prevent it from
 // being sent.
 func (s *serverMetricsRecorder) DeleteCPUUtilization() {
-	s.SetCPUUtilization(-1)
+	s.store.Delete(CPUUtilizationField)
 }
 
 // SetMemoryUtilization records a measurement for the memory utilization metric.
 func (s *serverMetricsRecorder) SetMemoryUtilization(val float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state.MemUtilization = val
+	s.store.Set(MemoryUtilizationField, val)
 }
 
 // DeleteMemoryUtilization deletes the relevant server metric to prevent it
 // from being sent.
 func (s *serverMetricsRecorder) DeleteMemoryUtilization() {
-	s.SetMemoryUtilization(-1)
+	s.store.Delete(MemoryUtilizationField)
 }
 
 // SetQPS records a measurement for the QPS metric.
 func (s *serverMetricsRecorder) SetQPS(val float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state.QPS = val
+	s.store.Set(QPSField, val)
 }
 
 // DeleteQPS deletes the relevant server metric to prevent it from being sent.
 func (s *serverMetricsRecorder) DeleteQPS() {
-	s.SetQPS(-1)
+	s.store.Delete(QPSField)
 }
 
 // SetEPS records a measurement for the EPS metric.
 func (s *serverMetricsRecorder) SetEPS(val float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state.EPS = val
+	s.store.Set(EPSField, val)
 }
 
 // DeleteEPS deletes the relevant server metric to prevent it from being sent.
 func (s *serverMetricsRecorder) DeleteEPS() {
-	s.SetEPS(-1)
+	s.store.Delete(EPSField)
 }
 
 // SetNamedUtilization records a measurement for a utilization metric uniquely
 // identifiable by name.
 func (s *serverMetricsRecorder) SetNamedUtilization(name string, val float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state.Utilization[name] = val
+	s.store.SetMap(UtilizationField, name, val)
 }
 
 // DeleteNamedUtilization deletes any previously recorded measurement for a
 // utilization metric uniquely identifiable by name.
 func (s *serverMetricsRecorder) DeleteNamedUtilization(name string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.state.Utilization, name)
+	s.store.DeleteMap(UtilizationField, name)
 }
 
 // SetRequestCost records a measurement for a utilization metric uniquely
 // identifiable by name.
 func (s *serverMetricsRecorder) SetRequestCost(name string, val float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state.RequestCost[name] = val
+	s.store.SetMap(RequestCostField, name, val)
 }
 
 // DeleteRequestCost deletes any previously recorded measurement for a
 // utilization metric uniquely identifiable by name.
 func (s *serverMetricsRecorder) DeleteRequestCost(name string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.state.RequestCost, name)
+	s.store.DeleteMap(RequestCostField, name)
 }
 
 // SetNamedMetric records a measurement for a utilization metric uniquely
 // identifiable by name.
 func (s *serverMetricsRecorder) SetNamedMetric(name string, val float64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state.NamedMetrics[name] = val
+	s.store.SetMap(NamedMetricsField, name, val)
 }
 
 // DeleteNamedMetric deletes any previously recorded measurement for a
 // utilization metric uniquely identifiable by name.
 func (s *serverMetricsRecorder) DeleteNamedMetric(name string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.state.NamedMetrics, name)
+	s.store.DeleteMap(NamedMetricsField, name)
 }